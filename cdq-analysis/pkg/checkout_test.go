@@ -0,0 +1,201 @@
+//
+// Copyright 2021-2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newTestRepo creates a local non-bare repo with a single commit on its
+// initial branch and a second branch "feature", so checkoutRevision has a branch,
+// a full SHA, and invalid revisions to exercise.
+func newTestRepo(t *testing.T) (*git.Repository, plumbing.Hash) {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "devfile.yaml"), []byte("schemaVersion: 2.2.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := worktree.Add("devfile.yaml"); err != nil {
+		t.Fatalf("failed to stage test file: %v", err)
+	}
+
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(0, 0)}
+	hash, err := worktree.Commit("initial commit", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	mainBranch := head.Name()
+
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+		t.Fatalf("failed to create feature branch: %v", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: mainBranch}); err != nil {
+		t.Fatalf("failed to switch back to the initial branch: %v", err)
+	}
+
+	return repo, hash
+}
+
+func TestCheckoutRevisionBranch(t *testing.T) {
+	repo, _ := newTestRepo(t)
+
+	if err := checkoutRevision(repo, "test-repo", "feature", nil); err != nil {
+		t.Fatalf("checkoutRevision(feature) returned unexpected error: %v", err)
+	}
+}
+
+func TestCheckoutRevisionFullSHA(t *testing.T) {
+	repo, hash := newTestRepo(t)
+
+	if err := checkoutRevision(repo, "test-repo", hash.String(), nil); err != nil {
+		t.Fatalf("checkoutRevision(%s) returned unexpected error: %v", hash.String(), err)
+	}
+}
+
+func TestCheckoutRevisionShortSHARejected(t *testing.T) {
+	repo, hash := newTestRepo(t)
+
+	err := checkoutRevision(repo, "test-repo", hash.String()[:8], nil)
+	if err == nil {
+		t.Fatal("checkoutRevision(short sha) expected an error, got nil")
+	}
+	if _, ok := err.(*ErrShortRef); !ok {
+		t.Fatalf("checkoutRevision(short sha) expected *ErrShortRef, got %T: %v", err, err)
+	}
+}
+
+func TestCheckoutRevisionNotFound(t *testing.T) {
+	repo, _ := newTestRepo(t)
+
+	err := checkoutRevision(repo, "test-repo", "does-not-exist", nil)
+	if err == nil {
+		t.Fatal("checkoutRevision(does-not-exist) expected an error, got nil")
+	}
+	if _, ok := err.(*ErrRevisionNotFound); !ok {
+		t.Fatalf("checkoutRevision(does-not-exist) expected *ErrRevisionNotFound, got %T: %v", err, err)
+	}
+}
+
+// newClonedLikeTestRepo reproduces the reference layout go-git's
+// PlainCloneContext actually leaves behind: a local branch only for the
+// default branch, plus refs/remotes/origin/* for every other branch on the
+// remote - never a local refs/heads/<other branch>. newTestRepo above
+// doesn't capture this because it creates the "feature" branch directly as
+// a local branch, which checkoutRevision could already handle.
+func newClonedLikeTestRepo(t *testing.T) *git.Repository {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "devfile.yaml"), []byte("schemaVersion: 2.2.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := worktree.Add("devfile.yaml"); err != nil {
+		t.Fatalf("failed to stage test file: %v", err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(0, 0)}
+	if _, err := worktree.Commit("initial commit", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	mainBranch := head.Name()
+
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+		t.Fatalf("failed to create feature branch: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "feature.txt"), []byte("feature\n"), 0644); err != nil {
+		t.Fatalf("failed to write feature file: %v", err)
+	}
+	if _, err := worktree.Add("feature.txt"); err != nil {
+		t.Fatalf("failed to stage feature file: %v", err)
+	}
+	featureHash, err := worktree.Commit("feature commit", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatalf("failed to commit on feature branch: %v", err)
+	}
+
+	// simulate what a clone leaves behind: a remote-tracking ref for the
+	// branch, but no local refs/heads/feature
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "feature"), featureHash)); err != nil {
+		t.Fatalf("failed to create remote-tracking ref: %v", err)
+	}
+	if err := repo.Storer.RemoveReference(plumbing.NewBranchReferenceName("feature")); err != nil {
+		t.Fatalf("failed to remove local feature branch: %v", err)
+	}
+
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: mainBranch}); err != nil {
+		t.Fatalf("failed to switch back to the initial branch: %v", err)
+	}
+
+	return repo
+}
+
+func TestCheckoutRevisionRemoteTrackingBranch(t *testing.T) {
+	repo := newClonedLikeTestRepo(t)
+
+	if err := checkoutRevision(repo, "test-repo", "feature", nil); err != nil {
+		t.Fatalf("checkoutRevision(feature) returned unexpected error: %v", err)
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName("feature"), true)
+	if err != nil {
+		t.Fatalf("expected a local feature branch to have been created, got error: %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD after checkout: %v", err)
+	}
+	if head.Name() != ref.Name() {
+		t.Fatalf("HEAD is on %q, want %q", head.Name(), ref.Name())
+	}
+}
@@ -0,0 +1,79 @@
+//
+// Copyright 2021-2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// defaultBranchCache memoizes ResolveDefaultBranch results for the lifetime
+// of the process, keyed by repo URL, so repeated calls for the same repo
+// (e.g. across multiple devfile lookups in a single CDQ) don't re-probe.
+var defaultBranchCache sync.Map // map[string]string
+
+// ResolveDefaultBranch figures out the default branch of repo when no
+// revision was supplied by the caller. ConvertGitHubURL used to hardcode
+// "main", which silently produced 404s for repos still defaulting to
+// "master". Rather than guessing at raw-content URL conventions (which
+// have no file to serve at a bare branch path and 404 regardless of
+// whether the branch exists), this does a real `git ls-remote` via go-git
+// and checks which of the provider's candidate branches actually has a
+// ref. The result is cached per repo for the remainder of the process
+// lifetime.
+func ResolveDefaultBranch(repo, token string) (string, error) {
+	if cached, ok := defaultBranchCache.Load(repo); ok {
+		return cached.(string), nil
+	}
+
+	provider, err := GetGitProvider(repo)
+	if err != nil {
+		return "", err
+	}
+
+	listOpts := &git.ListOptions{}
+	if token != "" {
+		listOpts.Auth = &githttp.BasicAuth{Username: tokenUsername(provider), Password: token}
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{normalizeGitURL(repo)},
+	})
+	refs, err := remote.List(listOpts)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve default branch for %q: %v", repo, err)
+	}
+
+	for _, candidate := range provider.DefaultBranchProbe() {
+		candidateRef := plumbing.NewBranchReferenceName(candidate)
+		for _, ref := range refs {
+			if ref.Name() == candidateRef {
+				defaultBranchCache.Store(repo, candidate)
+				return candidate, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("unable to resolve default branch for %q: none of the candidate branches (%s) exist", repo, strings.Join(provider.DefaultBranchProbe(), ", "))
+}
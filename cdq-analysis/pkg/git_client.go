@@ -0,0 +1,88 @@
+//
+// Copyright 2021-2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// GitClient is the interface consumers of application-service should depend
+// on instead of calling the package-level CloneRepo/GetBranchFromRepo/
+// ConvertGitHubURL/ValidateGithubURL functions directly. Depending on the
+// interface lets callers (and their own tests) substitute MockGitClient
+// instead of needing a real git binary or network access, mirroring the
+// mock pattern devfile/library adopted in pkg/git/mock.go.
+type GitClient interface {
+	// Clone clones gitURL.RepoURL to clonePath and checks out gitURL.Revision if set.
+	Clone(ctx context.Context, clonePath string, gitURL GitURL) error
+
+	// Checkout checks out revision in the repository already cloned at clonePath.
+	Checkout(clonePath, revision string) error
+
+	// CurrentBranch returns the currently checked out branch at clonePath.
+	CurrentBranch(clonePath string) (string, error)
+
+	// RawFileURL returns the raw-content URL for contextPath within repo at
+	// revision. token authenticates the default-branch probe when revision
+	// is empty.
+	RawFileURL(repo, revision, contextPath, token string) (string, error)
+
+	// ValidateURL returns nil if URL belongs to a supported git provider.
+	ValidateURL(URL string) error
+}
+
+// gitClient is the production GitClient backed by go-git.
+type gitClient struct{}
+
+// NewGitClient returns the production GitClient implementation.
+func NewGitClient() GitClient {
+	return &gitClient{}
+}
+
+func (c *gitClient) Clone(ctx context.Context, clonePath string, gitURL GitURL) error {
+	return CloneRepo(ctx, clonePath, gitURL)
+}
+
+func (c *gitClient) Checkout(clonePath, revision string) error {
+	repo, err := git.PlainOpen(clonePath)
+	if err != nil {
+		return fmt.Errorf("failed to open the repo at %q: %v", clonePath, err)
+	}
+	return checkoutRevision(repo, clonePath, revision, nil)
+}
+
+func (c *gitClient) CurrentBranch(clonePath string) (string, error) {
+	return GetBranchFromRepo(clonePath)
+}
+
+func (c *gitClient) RawFileURL(repo, revision, contextPath, token string) (string, error) {
+	provider, err := GetGitProvider(repo)
+	if err != nil {
+		return "", err
+	}
+	return provider.RawFileURL(repo, revision, contextPath, token)
+}
+
+func (c *gitClient) ValidateURL(URL string) error {
+	provider, err := GetGitProvider(URL)
+	if err != nil {
+		return err
+	}
+	return provider.ValidateHost(URL)
+}
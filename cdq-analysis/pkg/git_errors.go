@@ -0,0 +1,77 @@
+//
+// Copyright 2021-2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import "fmt"
+
+// ErrRepoNotFound is returned by CloneRepo when the remote repository could
+// not be found or is inaccessible with the given credentials.
+type ErrRepoNotFound struct {
+	URL string
+	Err error
+}
+
+func (e *ErrRepoNotFound) Error() string {
+	return fmt.Sprintf("repository %q not found: %v", e.URL, e.Err)
+}
+
+func (e *ErrRepoNotFound) Unwrap() error {
+	return e.Err
+}
+
+// ErrRevisionNotFound is returned by CloneRepo when the requested revision
+// does not exist in the cloned repository.
+type ErrRevisionNotFound struct {
+	URL      string
+	Revision string
+	Err      error
+}
+
+func (e *ErrRevisionNotFound) Error() string {
+	return fmt.Sprintf("revision %q not found in repository %q: %v", e.Revision, e.URL, e.Err)
+}
+
+func (e *ErrRevisionNotFound) Unwrap() error {
+	return e.Err
+}
+
+// ErrAuthFailed is returned by CloneRepo when the provided credentials
+// (token or SSH key) were rejected by the remote.
+type ErrAuthFailed struct {
+	URL string
+	Err error
+}
+
+func (e *ErrAuthFailed) Error() string {
+	return fmt.Sprintf("authentication failed for repository %q: %v", e.URL, e.Err)
+}
+
+func (e *ErrAuthFailed) Unwrap() error {
+	return e.Err
+}
+
+// ErrShortRef is returned when a revision looks like an abbreviated commit
+// SHA rather than a branch, tag, or full SHA. go-git (and similarly
+// nektos/act) cannot resolve short SHAs without walking the commit graph,
+// so we reject them up front with a clear error instead of failing deep
+// inside checkout.
+type ErrShortRef struct {
+	Revision string
+}
+
+func (e *ErrShortRef) Error() string {
+	return fmt.Sprintf("revision %q looks like an abbreviated commit SHA; a branch, tag, or full SHA is required", e.Revision)
+}
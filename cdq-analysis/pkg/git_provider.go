@@ -0,0 +1,190 @@
+//
+// Copyright 2021-2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// GitProvider abstracts the host-specific conventions needed to resolve raw
+// file URLs and inject credentials for a git repository. Implementations
+// exist for GitHub, GitLab, and Bitbucket; ConvertGitHubURL and
+// UpdateGitLink previously assumed GitHub only.
+type GitProvider interface {
+	// RawFileURL returns the URL that serves the raw content of contextPath
+	// at the given revision within repo. token authenticates the default
+	// branch probe for providers that need to look one up (empty revision);
+	// providers that don't need to probe the remote ignore it.
+	RawFileURL(repo, revision, contextPath, token string) (string, error)
+
+	// CloneURLWithToken returns repo's clone URL with token injected using
+	// the provider-appropriate credential scheme.
+	CloneURLWithToken(repo, token string) (string, error)
+
+	// ValidateHost returns nil if URL's host belongs to this provider.
+	ValidateHost(URL string) error
+
+	// DefaultBranchProbe returns the branch names to probe, in priority
+	// order, when no revision is specified for this provider.
+	DefaultBranchProbe() []string
+}
+
+// gitHubProvider implements GitProvider for github.com and raw.githubusercontent.com.
+type gitHubProvider struct{}
+
+// gitLabProvider implements GitProvider for gitlab.com.
+type gitLabProvider struct{}
+
+// bitbucketProvider implements GitProvider for bitbucket.org.
+type bitbucketProvider struct{}
+
+// GetGitProvider detects the provider from the URL's host and returns the
+// matching GitProvider implementation. Returns an InvalidURL error if the
+// host does not belong to a supported provider.
+func GetGitProvider(URL string) (GitProvider, error) {
+	parsedURL, err := url.Parse(URL)
+	if err != nil {
+		return nil, &InvalidURL{URL: URL, Err: err}
+	}
+
+	switch {
+	case strings.Contains(parsedURL.Host, "github"):
+		return gitHubProvider{}, nil
+	case strings.Contains(parsedURL.Host, "gitlab"):
+		return gitLabProvider{}, nil
+	case strings.Contains(parsedURL.Host, "bitbucket"):
+		return bitbucketProvider{}, nil
+	default:
+		return nil, &InvalidURL{URL: URL, Err: fmt.Errorf("host %q is not a supported git provider", parsedURL.Host)}
+	}
+}
+
+// tokenUsername returns the credential username go-git's BasicAuth should
+// pair with a token for provider, matching the scheme each provider expects
+// when the token is embedded in a clone URL (token:, oauth2:, x-token-auth:).
+func tokenUsername(provider GitProvider) string {
+	switch provider.(type) {
+	case gitLabProvider:
+		return "oauth2"
+	case bitbucketProvider:
+		return "x-token-auth"
+	default:
+		return "token"
+	}
+}
+
+func (gitHubProvider) RawFileURL(repo, revision, contextPath, token string) (string, error) {
+	return ConvertGitHubURL(repo, revision, contextPath, token)
+}
+
+func (gitHubProvider) CloneURLWithToken(repo, token string) (string, error) {
+	tempStr := strings.Split(repo, "https://")
+	if len(tempStr) < 2 {
+		return "", fmt.Errorf("unable to inject token into non-https GitHub URL %q", repo)
+	}
+	// e.g. https://token:<token>@github.com/owner/repoName.git
+	return fmt.Sprintf("https://token:%s@%s", token, tempStr[1]), nil
+}
+
+func (gitHubProvider) ValidateHost(URL string) error {
+	return ValidateGithubURL(URL)
+}
+
+func (gitHubProvider) DefaultBranchProbe() []string {
+	return []string{"main", "master"}
+}
+
+func (gitLabProvider) RawFileURL(repo, revision, contextPath, token string) (string, error) {
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	if revision == "" {
+		defaultBranch, err := ResolveDefaultBranch(repo, token)
+		if err != nil {
+			defaultBranch = "main"
+		}
+		revision = defaultBranch
+	}
+	rawURL := fmt.Sprintf("%s/-/raw/%s", repo, revision)
+	if contextPath != "" && contextPath != "./" && contextPath != "." {
+		rawURL = rawURL + "/" + strings.TrimPrefix(contextPath, "/")
+	}
+	return rawURL, nil
+}
+
+func (gitLabProvider) CloneURLWithToken(repo, token string) (string, error) {
+	tempStr := strings.Split(repo, "https://")
+	if len(tempStr) < 2 {
+		return "", fmt.Errorf("unable to inject token into non-https GitLab URL %q", repo)
+	}
+	// e.g. https://oauth2:<token>@gitlab.com/owner/repoName.git
+	return fmt.Sprintf("https://oauth2:%s@%s", token, tempStr[1]), nil
+}
+
+func (gitLabProvider) ValidateHost(URL string) error {
+	parsedURL, err := url.Parse(URL)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(parsedURL.Host, "gitlab") {
+		return nil
+	}
+	return fmt.Errorf("source git url %v is not from gitlab", URL)
+}
+
+func (gitLabProvider) DefaultBranchProbe() []string {
+	return []string{"main", "master"}
+}
+
+func (bitbucketProvider) RawFileURL(repo, revision, contextPath, token string) (string, error) {
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	if revision == "" {
+		defaultBranch, err := ResolveDefaultBranch(repo, token)
+		if err != nil {
+			defaultBranch = "main"
+		}
+		revision = defaultBranch
+	}
+	rawURL := fmt.Sprintf("%s/raw/%s", repo, revision)
+	if contextPath != "" && contextPath != "./" && contextPath != "." {
+		rawURL = rawURL + "/" + strings.TrimPrefix(contextPath, "/")
+	}
+	return rawURL, nil
+}
+
+func (bitbucketProvider) CloneURLWithToken(repo, token string) (string, error) {
+	tempStr := strings.Split(repo, "https://")
+	if len(tempStr) < 2 {
+		return "", fmt.Errorf("unable to inject token into non-https Bitbucket URL %q", repo)
+	}
+	// e.g. https://x-token-auth:<token>@bitbucket.org/owner/repoName.git
+	return fmt.Sprintf("https://x-token-auth:%s@%s", token, tempStr[1]), nil
+}
+
+func (bitbucketProvider) ValidateHost(URL string) error {
+	parsedURL, err := url.Parse(URL)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(parsedURL.Host, "bitbucket") {
+		return nil
+	}
+	return fmt.Errorf("source git url %v is not from bitbucket", URL)
+}
+
+func (bitbucketProvider) DefaultBranchProbe() []string {
+	return []string{"main", "master"}
+}
@@ -0,0 +1,66 @@
+//
+// Copyright 2021-2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import "context"
+
+// MockGitClient is a GitClient test double whose behavior is configured by
+// setting the *Func fields. Unset fields return a zero value and nil error,
+// so tests only need to stub the methods their scenario exercises.
+type MockGitClient struct {
+	CloneFunc         func(ctx context.Context, clonePath string, gitURL GitURL) error
+	CheckoutFunc      func(clonePath, revision string) error
+	CurrentBranchFunc func(clonePath string) (string, error)
+	RawFileURLFunc    func(repo, revision, contextPath, token string) (string, error)
+	ValidateURLFunc   func(URL string) error
+}
+
+var _ GitClient = (*MockGitClient)(nil)
+
+func (m *MockGitClient) Clone(ctx context.Context, clonePath string, gitURL GitURL) error {
+	if m.CloneFunc != nil {
+		return m.CloneFunc(ctx, clonePath, gitURL)
+	}
+	return nil
+}
+
+func (m *MockGitClient) Checkout(clonePath, revision string) error {
+	if m.CheckoutFunc != nil {
+		return m.CheckoutFunc(clonePath, revision)
+	}
+	return nil
+}
+
+func (m *MockGitClient) CurrentBranch(clonePath string) (string, error) {
+	if m.CurrentBranchFunc != nil {
+		return m.CurrentBranchFunc(clonePath)
+	}
+	return "", nil
+}
+
+func (m *MockGitClient) RawFileURL(repo, revision, contextPath, token string) (string, error) {
+	if m.RawFileURLFunc != nil {
+		return m.RawFileURLFunc(repo, revision, contextPath, token)
+	}
+	return "", nil
+}
+
+func (m *MockGitClient) ValidateURL(URL string) error {
+	if m.ValidateURLFunc != nil {
+		return m.ValidateURLFunc(URL)
+	}
+	return nil
+}
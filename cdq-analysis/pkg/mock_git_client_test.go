@@ -0,0 +1,85 @@
+//
+// Copyright 2021-2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// cloneAndReportBranch is representative of the kind of caller this backlog
+// introduced GitClient for: code that needs to clone a devfile repo and
+// report back the branch it landed on, without caring whether it's talking
+// to the real go-git-backed client or a test double.
+func cloneAndReportBranch(ctx context.Context, client GitClient, clonePath string, gitURL GitURL) (string, error) {
+	if err := client.Clone(ctx, clonePath, gitURL); err != nil {
+		return "", err
+	}
+	return client.CurrentBranch(clonePath)
+}
+
+func TestMockGitClientDrivesCaller(t *testing.T) {
+	var clonedURL string
+	mock := &MockGitClient{
+		CloneFunc: func(ctx context.Context, clonePath string, gitURL GitURL) error {
+			clonedURL = gitURL.RepoURL
+			return nil
+		},
+		CurrentBranchFunc: func(clonePath string) (string, error) {
+			return "main", nil
+		},
+	}
+
+	branch, err := cloneAndReportBranch(context.Background(), mock, "/tmp/devfile-repo", GitURL{RepoURL: "https://github.com/owner/repo"})
+	if err != nil {
+		t.Fatalf("cloneAndReportBranch returned unexpected error: %v", err)
+	}
+	if branch != "main" {
+		t.Errorf("cloneAndReportBranch returned branch %q, want %q", branch, "main")
+	}
+	if clonedURL != "https://github.com/owner/repo" {
+		t.Errorf("CloneFunc saw RepoURL %q, want %q", clonedURL, "https://github.com/owner/repo")
+	}
+}
+
+func TestMockGitClientPropagatesCloneError(t *testing.T) {
+	wantErr := errors.New("clone failed")
+	mock := &MockGitClient{
+		CloneFunc: func(ctx context.Context, clonePath string, gitURL GitURL) error {
+			return wantErr
+		},
+	}
+
+	_, err := cloneAndReportBranch(context.Background(), mock, "/tmp/devfile-repo", GitURL{RepoURL: "https://github.com/owner/repo"})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("cloneAndReportBranch error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestMockGitClientUnsetFuncsReturnZeroValues(t *testing.T) {
+	mock := &MockGitClient{}
+
+	if err := mock.Clone(context.Background(), "/tmp/x", GitURL{}); err != nil {
+		t.Errorf("Clone with unset CloneFunc returned %v, want nil", err)
+	}
+	if err := mock.Checkout("/tmp/x", "main"); err != nil {
+		t.Errorf("Checkout with unset CheckoutFunc returned %v, want nil", err)
+	}
+	if err := mock.ValidateURL("https://github.com/owner/repo"); err != nil {
+		t.Errorf("ValidateURL with unset ValidateURLFunc returned %v, want nil", err)
+	}
+}
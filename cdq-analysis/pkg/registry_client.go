@@ -0,0 +1,373 @@
+//
+// Copyright 2021-2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devfile/alizer/pkg/apis/model"
+	"github.com/devfile/registry-support/index/generator/schema"
+	registryLibrary "github.com/devfile/registry-support/registry-library/library"
+)
+
+// RegistryOptions configures a RegistryClient. The zero value is usable: it
+// falls back to http.DefaultClient, HTTPRequestResponseTimeout, no on-disk
+// cache, and no telemetry hook.
+type RegistryOptions struct {
+	// HTTPClient is used for the revalidation GET request issued directly
+	// against the registry's index endpoint once CacheTTL has elapsed.
+	// registryLibrary.GetRegistryIndex (used for the actual index fetch)
+	// has no client-injection hook of its own - it only exposes
+	// HTTPTimeout - so revalidation is done out of band from this client.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Timeout bounds each registry fetch, translated into
+	// registryLibrary.RegistryOptions.HTTPTimeout. Defaults to
+	// HTTPRequestResponseTimeout.
+	Timeout time.Duration
+
+	// CacheDir, if set, persists each registry's index to disk so a
+	// restart doesn't require refetching every registry on first use.
+	CacheDir string
+
+	// CacheTTL is how long a cached index is served without revalidation.
+	// After it elapses, the next call revalidates the cached index and
+	// refetches it if it changed, synchronously, before returning; the
+	// stale cached index is served only when that refetch fails. No
+	// refresh runs in the background. Defaults to 10 minutes.
+	CacheTTL time.Duration
+
+	// OnTelemetry, if set, is invoked after every successful registry fetch
+	// with the registry URL and the TelemetryData sent with that request.
+	// The client always sends an empty TelemetryData.
+	OnTelemetry func(registryURL string, telemetry registryLibrary.TelemetryData)
+}
+
+func (o RegistryOptions) httpClient() *http.Client {
+	if o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (o RegistryOptions) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return HTTPRequestResponseTimeout
+}
+
+func (o RegistryOptions) httpTimeoutSeconds() int {
+	seconds := int(o.timeout() / time.Second)
+	if seconds <= 0 {
+		seconds = 1
+	}
+	return seconds
+}
+
+func (o RegistryOptions) cacheTTL() time.Duration {
+	if o.CacheTTL > 0 {
+		return o.CacheTTL
+	}
+	return 10 * time.Minute
+}
+
+// cachedIndex holds a registry's index together with the bookkeeping
+// needed for stale-while-revalidate and ETag-based conditional GETs.
+type cachedIndex struct {
+	entries      []schema.Schema
+	etag         string
+	lastModified string
+	fetchedAt    time.Time
+}
+
+// RegistryClient aggregates one or more devfile registries behind a single
+// API, merging their indices with first-match-wins conflict resolution
+// (earlier entries in registryURLs take priority) and caching each
+// registry's index on disk with TTL and ETag/Last-Modified revalidation.
+// It replaces the ad hoc getAlizerDevfileTypes/GetRepoFromRegistry calls
+// that each repeated their own registryLibrary.GetRegistryIndex plumbing.
+type RegistryClient struct {
+	registryURLs []string
+	options      RegistryOptions
+
+	mu    sync.Mutex
+	cache map[string]*cachedIndex
+}
+
+// NewRegistryClient creates a RegistryClient over registryURLs, queried and
+// merged in the given priority order.
+func NewRegistryClient(registryURLs []string, options RegistryOptions) *RegistryClient {
+	return &RegistryClient{
+		registryURLs: registryURLs,
+		options:      options,
+		cache:        map[string]*cachedIndex{},
+	}
+}
+
+// ListSamples returns the merged sample index across all configured
+// registries. When two registries define a sample with the same name, the
+// entry from the earlier registry in registryURLs wins.
+func (c *RegistryClient) ListSamples() ([]schema.Schema, error) {
+	seen := map[string]bool{}
+	merged := []schema.Schema{}
+
+	for _, registryURL := range c.registryURLs {
+		index, err := c.getIndex(registryURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the registry index from %q: %v", registryURL, err)
+		}
+
+		for _, entry := range index {
+			if seen[entry.Name] {
+				continue
+			}
+			seen[entry.Name] = true
+			merged = append(merged, entry)
+		}
+	}
+
+	return merged, nil
+}
+
+// GetSample returns the sample named name from the merged registry index.
+func (c *RegistryClient) GetSample(name string) (schema.Schema, error) {
+	samples, err := c.ListSamples()
+	if err != nil {
+		return schema.Schema{}, err
+	}
+
+	for _, sample := range samples {
+		if sample.Name == name {
+			return sample, nil
+		}
+	}
+
+	return schema.Schema{}, fmt.Errorf("unable to find sample with a name %s in the registry", name)
+}
+
+// MatchDevfileTypes converts the merged registry index into the
+// alizer model.DevfileType shape consumed by language-detection matching,
+// equivalent to the old per-registry getAlizerDevfileTypes.
+func (c *RegistryClient) MatchDevfileTypes() ([]model.DevfileType, error) {
+	samples, err := c.ListSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]model.DevfileType, 0, len(samples))
+	for _, sample := range samples {
+		types = append(types, model.DevfileType{
+			Name:        sample.Name,
+			Language:    sample.Language,
+			ProjectType: sample.ProjectType,
+			Tags:        sample.Tags,
+		})
+	}
+
+	return types, nil
+}
+
+// registryIndexPath is the devfile registry REST endpoint that serves the
+// v2 schema index registryLibrary.GetRegistryIndex fetches under the hood;
+// it's what revalidate conditionally GETs.
+const registryIndexPath = "/v2index"
+
+// getIndex returns registryURL's index, serving the on-disk/in-memory cache
+// within CacheTTL, and revalidating (via ETag/Last-Modified) once the TTL
+// has elapsed. If revalidation fails, a full fetch is attempted, and a
+// stale cached index is still returned if that fetch fails, so registry
+// hiccups don't break callers. When revalidation reports
+// the index changed, at most one further request is made to fetch it - the
+// ETag/Last-Modified captured during revalidation is reused rather than
+// requested again.
+func (c *RegistryClient) getIndex(registryURL string) ([]schema.Schema, error) {
+	c.mu.Lock()
+	cached, ok := c.cache[registryURL]
+	c.mu.Unlock()
+
+	if !ok {
+		cached = c.loadFromDisk(registryURL)
+	}
+
+	if cached != nil && time.Since(cached.fetchedAt) < c.options.cacheTTL() {
+		return cached.entries, nil
+	}
+
+	etag, lastModified := "", ""
+	if cached != nil {
+		notModified, e, lm, err := c.revalidate(registryURL, cached)
+		if err == nil && notModified {
+			cached.fetchedAt = time.Now()
+			cached.etag, cached.lastModified = e, lm
+			c.mu.Lock()
+			c.cache[registryURL] = cached
+			c.mu.Unlock()
+			c.saveToDisk(registryURL, cached)
+			return cached.entries, nil
+		}
+		if err == nil {
+			// the index changed; reuse the ETag/Last-Modified this same
+			// request already returned instead of issuing a second
+			// revalidate request below purely to capture them.
+			etag, lastModified = e, lm
+		}
+	}
+
+	fresh, err := c.fetch(registryURL, etag, lastModified)
+	if err != nil {
+		if cached != nil {
+			// the refetch failed: serve the stale index rather than fail
+			return cached.entries, nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cache[registryURL] = fresh
+	c.mu.Unlock()
+	c.saveToDisk(registryURL, fresh)
+
+	return fresh.entries, nil
+}
+
+// revalidate issues a conditional GET against registryURL's index endpoint
+// using cached's ETag/Last-Modified. It reports notModified=true only on an
+// explicit 304 response; any other status, or a request error, means the
+// caller should fall back to a full fetch.
+func (c *RegistryClient) revalidate(registryURL string, cached *cachedIndex) (notModified bool, etag, lastModified string, err error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(registryURL, "/")+registryIndexPath, nil)
+	if err != nil {
+		return false, "", "", err
+	}
+	if cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+	if cached.lastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.lastModified)
+	}
+
+	client := c.options.httpClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return true, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), nil
+	}
+	return false, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), nil
+}
+
+// fetch fetches registryURL's full index via registryLibrary.GetRegistryIndex.
+// etag and lastModified are stored alongside the result for the next call to
+// revalidate against; fetch itself never issues its own revalidation
+// request for them - getIndex already has them from the revalidate call
+// that determined the cached index was stale (or passes empty strings on
+// to a brand new registryURL with nothing cached yet).
+func (c *RegistryClient) fetch(registryURL, etag, lastModified string) (*cachedIndex, error) {
+	timeout := c.options.httpTimeoutSeconds()
+	telemetry := registryLibrary.TelemetryData{}
+	entries, err := registryLibrary.GetRegistryIndex(registryURL, registryLibrary.RegistryOptions{
+		Telemetry:   telemetry,
+		HTTPTimeout: &timeout,
+	}, schema.SampleDevfileType)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.options.OnTelemetry != nil {
+		c.options.OnTelemetry(registryURL, telemetry)
+	}
+
+	return &cachedIndex{entries: entries, etag: etag, lastModified: lastModified, fetchedAt: time.Now()}, nil
+}
+
+// cacheFilePath returns the on-disk cache file for registryURL, named by
+// its hash so arbitrary registry URLs are safe path components.
+func (c *RegistryClient) cacheFilePath(registryURL string) string {
+	sum := sha256.Sum256([]byte(registryURL))
+	return filepath.Join(c.options.CacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *RegistryClient) loadFromDisk(registryURL string) *cachedIndex {
+	if c.options.CacheDir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.cacheFilePath(registryURL))
+	if err != nil {
+		return nil
+	}
+
+	var onDisk struct {
+		Entries      []schema.Schema `json:"entries"`
+		ETag         string          `json:"etag"`
+		LastModified string          `json:"lastModified"`
+		FetchedAt    time.Time       `json:"fetchedAt"`
+	}
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		return nil
+	}
+
+	cached := &cachedIndex{
+		entries:      onDisk.Entries,
+		etag:         onDisk.ETag,
+		lastModified: onDisk.LastModified,
+		fetchedAt:    onDisk.FetchedAt,
+	}
+	c.mu.Lock()
+	c.cache[registryURL] = cached
+	c.mu.Unlock()
+	return cached
+}
+
+func (c *RegistryClient) saveToDisk(registryURL string, index *cachedIndex) {
+	if c.options.CacheDir == "" {
+		return
+	}
+
+	if err := os.MkdirAll(c.options.CacheDir, 0750); err != nil {
+		return
+	}
+
+	onDisk := struct {
+		Entries      []schema.Schema `json:"entries"`
+		ETag         string          `json:"etag"`
+		LastModified string          `json:"lastModified"`
+		FetchedAt    time.Time       `json:"fetchedAt"`
+	}{Entries: index.entries, ETag: index.etag, LastModified: index.lastModified, FetchedAt: index.fetchedAt}
+
+	data, err := json.Marshal(onDisk)
+	if err != nil {
+		return
+	}
+
+	// best-effort: a failed cache write shouldn't fail the caller, it just
+	// means the next process start refetches instead of warming from disk
+	_ = os.WriteFile(c.cacheFilePath(registryURL), data, 0640)
+}
@@ -16,12 +16,13 @@
 package pkg
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -29,8 +30,11 @@ import (
 	"time"
 
 	"github.com/devfile/alizer/pkg/apis/model"
-	"github.com/devfile/registry-support/index/generator/schema"
-	registryLibrary "github.com/devfile/registry-support/registry-library/library"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 )
 
 const (
@@ -41,80 +45,206 @@ type GitURL struct {
 	RepoURL  string // the repo URL where the devfile is located
 	Revision string
 	Token    string // TODO: Token should not be exported/exposed via GitURL. CRUD ops should be used to access token
+	Context  string // the devfile context path within the repo; when set, CloneRepo sparse-checks-out only this directory
+
+	// SSHKeyPath and SSHKeyBytes configure SSH auth for RepoURLs in
+	// git@host:owner/repo or ssh://... form. At most one should be set;
+	// SSHKeyPath is read from disk, SSHKeyBytes is used as-is. Ignored for
+	// https:// URLs.
+	SSHKeyPath  string
+	SSHKeyBytes []byte
 }
 
-const (
-	RepoNotFoundMsg         = "repository .* not found"
-	RevisionNotFoundMsg     = "pathspec .* did not match any file(s) known to git"
-	AuthenticationFailedMsg = "Authentication failed .*"
-)
+// isSSHURL reports whether repoURL is an SSH-style git remote, either the
+// scp-like shorthand (git@host:owner/repo) or an explicit ssh:// URL.
+func isSSHURL(repoURL string) bool {
+	if strings.HasPrefix(repoURL, "ssh://") {
+		return true
+	}
+	matched, _ := regexp.MatchString(`^[\w-]+@[\w.-]+:`, repoURL)
+	return matched
+}
+
+// normalizeGitURL canonicalizes .git suffix handling across HTTPS and SSH
+// URL forms so callers don't have to special-case either when comparing or
+// caching by URL.
+func normalizeGitURL(repoURL string) string {
+	repoURL = strings.TrimSuffix(repoURL, "/")
+	if !strings.HasSuffix(repoURL, ".git") {
+		repoURL = repoURL + ".git"
+	}
+	return repoURL
+}
+
+// sshAuth builds the go-git SSH auth method for gitURL, preferring
+// SSHKeyBytes over SSHKeyPath when both are set, falling back to the
+// user's SSH agent when neither is provided.
+func sshAuth(gitURL GitURL) (transport.AuthMethod, error) {
+	if len(gitURL.SSHKeyBytes) > 0 {
+		return ssh.NewPublicKeys("git", gitURL.SSHKeyBytes, "")
+	}
+	if gitURL.SSHKeyPath != "" {
+		return ssh.NewPublicKeysFromFile("git", gitURL.SSHKeyPath, "")
+	}
+	return ssh.NewSSHAgentAuth("git")
+}
 
-// CloneRepo clones the repoURL to specfied clonePath
-func CloneRepo(clonePath string, gitURL GitURL) error {
+// CloneRepo clones the repoURL to the specified clonePath using go-git
+// in-process rather than shelling out to the git binary. ctx governs
+// cancellation/timeout of the clone; the checkout that follows it does not
+// observe ctx. If gitURL.Context is
+// set, the worktree is sparse-checked-out to that subdirectory afterwards,
+// so only the devfile context path is materialized on disk.
+func CloneRepo(ctx context.Context, clonePath string, gitURL GitURL) error {
 	exist, err := IsExist(clonePath)
 	if !exist || err != nil {
 		os.MkdirAll(clonePath, 0750)
 	}
-	cloneURL := gitURL.RepoURL
-	// Execute does an exec.Command on the specified command
-	if gitURL.Token != "" {
-		tempStr := strings.Split(gitURL.RepoURL, "https://")
 
-		// e.g. https://token:<token>@github.com/owner/repoName.git
-		cloneURL = fmt.Sprintf("https://token:%s@%s", gitURL.Token, tempStr[1])
+	cloneURL := normalizeGitURL(gitURL.RepoURL)
+	cloneOpts := &git.CloneOptions{
+		URL:   cloneURL,
+		Depth: 1,
 	}
-	c := exec.Command("git", "clone", cloneURL, clonePath)
-	c.Dir = clonePath
 
-	// set env to skip authentication prompt and directly error out
-	c.Env = os.Environ()
-	c.Env = append(c.Env, "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=/bin/echo")
+	switch {
+	case isSSHURL(gitURL.RepoURL):
+		auth, err := sshAuth(gitURL)
+		if err != nil {
+			return err
+		}
+		cloneOpts.Auth = auth
+	case gitURL.Token != "":
+		provider, err := GetGitProvider(gitURL.RepoURL)
+		if err != nil {
+			return err
+		}
+		tokenURL, err := provider.CloneURLWithToken(cloneURL, gitURL.Token)
+		if err != nil {
+			return err
+		}
+		cloneURL = tokenURL
+		cloneOpts.URL = cloneURL
+		cloneOpts.Auth = &githttp.BasicAuth{Username: tokenUsername(provider), Password: gitURL.Token}
+	}
 
-	output, err := c.CombinedOutput()
+	// isolate the clone from the host's user/system gitconfig so clone
+	// behavior is reproducible across environments and CI runners.
+	// Note: when gitURL.Revision is empty we deliberately don't call
+	// ResolveDefaultBranch here - go-git, like the git binary, already
+	// checks out whatever branch the remote's HEAD points at, so a
+	// "master"-default repo works without any probing.
+	repo, err := git.PlainCloneContext(ctx, clonePath, false, cloneOpts)
 	if err != nil {
-
-		if matched, _ := regexp.MatchString(RepoNotFoundMsg, string(output)); matched {
-			return &RepoNotFound{URL: cloneURL, Err: err}
-		} else if matched, _ := regexp.MatchString(AuthenticationFailedMsg, string(output)); matched {
-			return &AuthenticationFailed{URL: cloneURL, Err: err}
+		if errors.Is(err, transport.ErrRepositoryNotFound) {
+			return &ErrRepoNotFound{URL: cloneURL, Err: err}
+		}
+		if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+			return &ErrAuthFailed{URL: cloneURL, Err: err}
 		}
-
 		return fmt.Errorf("failed to clone the repo: %v", err)
 	}
 
-	if gitURL.Revision != "" {
-		c = exec.Command("git", "checkout", gitURL.Revision)
-		c.Dir = clonePath
+	// go-git's CloneOptions has no sparse-checkout field (unlike the git
+	// binary's --sparse/--filter); sparse checkout is only available as a
+	// CheckoutOptions passed to worktree.Checkout, so it's applied here as
+	// a second step, same as go-git's own sparse-checkout example.
+	var sparseDirs []string
+	if gitURL.Context != "" && gitURL.Context != "." && gitURL.Context != "./" {
+		sparseDirs = []string{strings.TrimPrefix(gitURL.Context, "/")}
+	}
 
-		_, err = c.CombinedOutput()
+	if gitURL.Revision != "" {
+		if err := checkoutRevision(repo, cloneURL, gitURL.Revision, sparseDirs); err != nil {
+			return err
+		}
+	} else if len(sparseDirs) > 0 {
+		worktree, err := repo.Worktree()
 		if err != nil {
-			if matched, _ := regexp.MatchString(RevisionNotFoundMsg, string(output)); matched {
-				return &RepoNotFound{URL: cloneURL, Revision: gitURL.Revision, Err: err}
+			return fmt.Errorf("failed to get the worktree: %v", err)
+		}
+		if err := worktree.Checkout(&git.CheckoutOptions{SparseCheckoutDirectories: sparseDirs}); err != nil {
+			return fmt.Errorf("failed to sparse checkout %v: %v", sparseDirs, err)
+		}
+	}
+
+	return nil
+}
+
+// checkoutRevision checks out revision in repo, trying it first as a local
+// branch, then as an origin remote-tracking branch (creating a local branch
+// from it), and finally resolving it as a tag or full SHA. sparseDirs,
+// when non-empty, restricts the resulting worktree to those directories.
+func checkoutRevision(repo *git.Repository, cloneURL, revision string, sparseDirs []string) error {
+	if isShortRef(revision) {
+		return &ErrShortRef{Revision: revision}
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get the worktree: %v", err)
+	}
+
+	checkoutOpts := &git.CheckoutOptions{
+		Branch:                    plumbing.NewBranchReferenceName(revision),
+		SparseCheckoutDirectories: sparseDirs,
+	}
+	if err := worktree.Checkout(checkoutOpts); err != nil {
+		// revision isn't a local branch. Unlike the git binary's DWIM
+		// behavior (which auto-creates a local tracking branch the first
+		// time you check out an unambiguous remote branch name), go-git
+		// only ever creates refs/heads/<default> on clone, so most branch
+		// names need to be resolved against refs/remotes/origin/<revision>
+		// and turned into a local branch explicitly.
+		if remoteRef, refErr := repo.Reference(plumbing.NewRemoteReferenceName("origin", revision), true); refErr == nil {
+			trackingCheckoutOpts := &git.CheckoutOptions{
+				Hash:                      remoteRef.Hash(),
+				Branch:                    plumbing.NewBranchReferenceName(revision),
+				Create:                    true,
+				SparseCheckoutDirectories: sparseDirs,
+			}
+			if err := worktree.Checkout(trackingCheckoutOpts); err == nil {
+				return nil
 			}
+		}
 
-			return fmt.Errorf("failed to checkout the revision %q: %v", gitURL.Revision, err)
+		// not a remote branch either; fall back to resolving it as a tag or full SHA
+		hash, resolveErr := repo.ResolveRevision(plumbing.Revision(revision))
+		if resolveErr != nil {
+			return &ErrRevisionNotFound{URL: cloneURL, Revision: revision, Err: resolveErr}
+		}
+		if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash, SparseCheckoutDirectories: sparseDirs}); err != nil {
+			return &ErrRevisionNotFound{URL: cloneURL, Revision: revision, Err: err}
 		}
 	}
 
 	return nil
 }
 
+// isShortRef reports whether revision looks like an abbreviated commit SHA
+// (7 to 39 lowercase hex characters) rather than a branch, tag, or
+// full SHA go-git can resolve unambiguously.
+func isShortRef(revision string) bool {
+	if len(revision) >= 7 && len(revision) < 40 {
+		matched, _ := regexp.MatchString("^[0-9a-f]+$", revision)
+		return matched
+	}
+	return false
+}
+
 // GetBranchFromRepo gets the current branch from the cloned repository
 func GetBranchFromRepo(clonePath string) (string, error) {
-	// Command we want to run is: git rev-parse --abbrev-ref HEAD
-	c := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	c.Dir = clonePath
+	repo, err := git.PlainOpen(clonePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open the repo at %q: %v", clonePath, err)
+	}
 
-	// Get the output of the command
-	branchBytes, err := c.CombinedOutput()
+	head, err := repo.Head()
 	if err != nil {
 		return "", fmt.Errorf("failed to get the branch from the repo: %v", err)
 	}
-	branch := string(branchBytes)
 
-	// Remove newline characters potentially present
-	branch = strings.Split(branch, "\n")[0]
-	return branch, nil
+	return head.Name().Short(), nil
 }
 
 // CurlEndpoint curls the endpoint and returns the response or an error if the response is a non-200 status
@@ -155,9 +285,12 @@ func CurlEndpoint(endpoint, token string) ([]byte, error) {
 	return nil, fmt.Errorf("received a non-200 status when curling %s", endpoint)
 }
 
-// ConvertGitHubURL converts a git url to its raw format
+// ConvertGitHubURL converts a git url to its raw format. token, if set, is
+// used to authenticate the default-branch probe (see ResolveDefaultBranch)
+// when revision is empty, so private repos resolve their actual default
+// branch instead of silently falling back to "main".
 // adapted from https://github.com/redhat-developer/odo/blob/e63773cc156ade6174a533535cbaa0c79506ffdb/pkg/catalog/catalog.go#L72
-func ConvertGitHubURL(URL string, revision string, context string) (string, error) {
+func ConvertGitHubURL(URL string, revision string, context string, token string) (string, error) {
 	// If the URL ends with .git, remove it
 	// The regex will only instances of '.git' if it is at the end of the given string
 	reg := regexp.MustCompile(".git$")
@@ -181,8 +314,14 @@ func ConvertGitHubURL(URL string, revision string, context string) (string, erro
 			// Add revision for GitHub raw URL
 			URL = URL + "/" + revision
 		} else {
-			// Add "main" branch for GitHub raw URL by default if revision is not specified
-			URL = URL + "/main"
+			// No revision specified; probe for the repo's default branch
+			// rather than assuming "main", since repos that still default
+			// to "master" would otherwise 404.
+			defaultBranch, err := ResolveDefaultBranch(URL, token)
+			if err != nil {
+				defaultBranch = "main"
+			}
+			URL = URL + "/" + defaultBranch
 		}
 		if context != "" && context != "./" && context != "." {
 			// trim the prefix / in context
@@ -213,42 +352,21 @@ func IsExist(path string) (bool, error) {
 
 // getAlizerDevfileTypes gets the Alizer devfile types for a specified registry
 func getAlizerDevfileTypes(registryURL string) ([]model.DevfileType, error) {
-	types := []model.DevfileType{}
-	registryIndex, err := registryLibrary.GetRegistryIndex(registryURL, registryLibrary.RegistryOptions{
-		Telemetry: registryLibrary.TelemetryData{},
-	}, schema.SampleDevfileType)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, index := range registryIndex {
-		types = append(types, model.DevfileType{
-			Name:        index.Name,
-			Language:    index.Language,
-			ProjectType: index.ProjectType,
-			Tags:        index.Tags,
-		})
-	}
-
-	return types, nil
+	return NewRegistryClient([]string{registryURL}, RegistryOptions{}).MatchDevfileTypes()
 }
 
 // GetRepoFromRegistry gets the sample repo link from the devfile registry
 func GetRepoFromRegistry(name, registryURL string) (string, error) {
-	registryIndex, err := registryLibrary.GetRegistryIndex(registryURL, registryLibrary.RegistryOptions{
-		Telemetry: registryLibrary.TelemetryData{},
-	}, schema.SampleDevfileType)
+	sample, err := NewRegistryClient([]string{registryURL}, RegistryOptions{}).GetSample(name)
 	if err != nil {
 		return "", err
 	}
 
-	for _, index := range registryIndex {
-		if index.Name == name && index.Git != nil && index.Git.Remotes["origin"] != "" {
-			return index.Git.Remotes["origin"], nil
-		}
+	if sample.Git == nil || sample.Git.Remotes["origin"] == "" {
+		return "", fmt.Errorf("unable to find sample with a name %s in the registry", name)
 	}
 
-	return "", fmt.Errorf("unable to find sample with a name %s in the registry", name)
+	return sample.Git.Remotes["origin"], nil
 }
 
 // getContext returns the context backtracking from the end of the localpath
@@ -264,20 +382,21 @@ func getContext(localpath string, currentLevel int) string {
 }
 
 // UpdateGitLink updates the relative uri
-// to a full URL link with the context & revision
-func UpdateGitLink(repo, revision, context string) (string, error) {
-	var rawGitURL string
-	var err error
-	if !strings.HasPrefix(context, "http") {
-		rawGitURL, err = ConvertGitHubURL(repo, revision, context)
-		if err != nil {
-			return "", err
-		}
-
-	} else {
+// to a full URL link with the context & revision, dispatching to the
+// repo's GitProvider so GitLab and Bitbucket repos get their own raw-file
+// URL conventions instead of being forced through GitHub's. token, if set,
+// authenticates the provider's default-branch probe when revision is empty.
+func UpdateGitLink(repo, revision, context, token string) (string, error) {
+	if strings.HasPrefix(context, "http") {
 		return context, nil
 	}
-	return rawGitURL, nil
+
+	provider, err := GetGitProvider(repo)
+	if err != nil {
+		return "", err
+	}
+
+	return provider.RawFileURL(repo, revision, context, token)
 }
 
 // ValidateGithubURL checks if the given url includes github in hostname